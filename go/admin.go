@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// readyQueueThreshold bounds how backed up the worker pool may be for
+// /readyz to still report ready; beyond it the collector is considered
+// draining rather than healthy, so an orchestrator can hold off sending
+// more work (e.g. a rolling restart) until it catches up.
+const readyQueueThreshold = 1000
+
+// AdminServer exposes /healthz, /readyz and /metrics for deployments
+// where the collector runs as a long-lived service on a fleet host,
+// rather than a one-shot CLI invocation.
+type AdminServer struct {
+	server  *http.Server
+	reached int32 // set once Thunderstorm has been reached successfully
+}
+
+// NewAdminServer builds an admin server listening on addr. It does not
+// start listening until Start is called.
+func NewAdminServer(addr string) *AdminServer {
+	admin := &AdminServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", admin.handleHealthz)
+	mux.HandleFunc("/readyz", admin.handleReadyz)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	admin.server = &http.Server{Addr: addr, Handler: mux}
+	return admin
+}
+
+// Start begins serving in the background. Errors other than the server
+// being closed are reported on the returned channel.
+func (a *AdminServer) Start() <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return errs
+}
+
+// Shutdown gracefully stops the admin server.
+func (a *AdminServer) Shutdown(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
+// MarkReached records that Thunderstorm has been reached at least once,
+// which /readyz requires before reporting ready.
+func (a *AdminServer) MarkReached() {
+	atomic.StoreInt32(&a.reached, 1)
+}
+
+func (a *AdminServer) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+func (a *AdminServer) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	if atomic.LoadInt32(&a.reached) == 0 {
+		http.Error(w, "thunderstorm not yet reached", http.StatusServiceUnavailable)
+		return
+	}
+	if depth := currentQueueDepth(); depth > readyQueueThreshold {
+		http.Error(w, fmt.Sprintf("worker pool draining: queue depth %d", depth), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ready")
+}
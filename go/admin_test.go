@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthzAlwaysOK(t *testing.T) {
+	admin := NewAdminServer(":0")
+	w := httptest.NewRecorder()
+	admin.handleHealthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestReadyzBeforeReached(t *testing.T) {
+	admin := NewAdminServer(":0")
+
+	w := httptest.NewRecorder()
+	admin.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d before MarkReached", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestReadyzAfterReachedWithShallowQueue(t *testing.T) {
+	admin := NewAdminServer(":0")
+	admin.MarkReached()
+	SetQueueDepth(0)
+	defer SetQueueDepth(0)
+
+	w := httptest.NewRecorder()
+	admin.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d once reached with a shallow queue", w.Code, http.StatusOK)
+	}
+}
+
+func TestReadyzAtQueueThreshold(t *testing.T) {
+	admin := NewAdminServer(":0")
+	admin.MarkReached()
+	SetQueueDepth(readyQueueThreshold)
+	defer SetQueueDepth(0)
+
+	w := httptest.NewRecorder()
+	admin.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d at exactly readyQueueThreshold", w.Code, http.StatusOK)
+	}
+}
+
+func TestReadyzQueueDraining(t *testing.T) {
+	admin := NewAdminServer(":0")
+	admin.MarkReached()
+	SetQueueDepth(readyQueueThreshold + 1)
+	defer SetQueueDepth(0)
+
+	w := httptest.NewRecorder()
+	admin.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d when queue depth exceeds readyQueueThreshold", w.Code, http.StatusServiceUnavailable)
+	}
+}
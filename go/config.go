@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,7 +16,7 @@ type Config struct {
 	Server         string   `yaml:"thunderstorm-server" shorthand:"s" description:"Thunderstorm URL to which files should be uploaded.\nExample: --thunderstorm-server https://my.thunderstorm:8080/"`
 	Sync           bool     `yaml:"upload-synchronous" description:"Whether files should be uploaded synchronously to Thunderstorm. If yes, the collector takes longer, but displays the results of all scanned files."`
 	Debug          bool     `yaml:"debug" description:"Print debugging information." hidden:"true"`
-	Threads        int      `yaml:"threads" description:"How many threads should upload files simultaneously." shorthand:"r"`
+	Threads        int      `yaml:"threads" description:"How many dispatch goroutines should upload files simultaneously.\nOver HTTP/2 these share a pooled set of connections per host rather than opening one connection each." shorthand:"r"`
 	MaxFileSize    int64    `yaml:"max-filesize" description:"Maximum file size up to which files should be uploaded (in MB)." shorthand:"m"`
 	Proxy          string   `yaml:"http-proxy" description:"Proxy that should be used for the connection to Thunderstorm.\nIf left empty, the proxy is filled from the HTTP_PROXY and HTTPS_PROXY environment variables."`
 	CAs            []string `yaml:"ca" description:"Path to a PEM CA certificate that signed the HTTPS certificate of the Thunderstorm server.\nSpecify multiple CAs by using this flag multiple times."`
@@ -24,13 +25,43 @@ type Config struct {
 	Source         string   `yaml:"source" description:"Name for this device in the Thunderstorm log messages." shorthand:"o"`
 	Template       string   `flag:"template" description:"Process default scan parameters from this YAML file." shorthand:"t"`
 	Help           bool     `flag:"help" description:"Show this help." shorthand:"h"`
+
+	ChunkSize   int64  `yaml:"chunk-size" description:"Size (in MB) of the chunks used when uploading files with --resume.\nFiles smaller than this are still uploaded in a single request."`
+	ResumeState string `yaml:"resume-state" description:"Path to the journal file that tracks per-file upload offsets.\nRequired when --resume is set."`
+	Resume      bool   `yaml:"resume" description:"Resume interrupted uploads using the journal at --resume-state instead of re-uploading files from scratch."`
+
+	MaxConnsPerHost     int           `yaml:"max-conns-per-host" description:"Maximum number of connections (HTTP/2 streams are multiplexed over these) kept open to the Thunderstorm server."`
+	MaxIdleConnsPerHost int           `yaml:"max-idle-conns-per-host" description:"Maximum number of idle connections to the Thunderstorm server kept around for reuse."`
+	IdleConnTimeout     time.Duration `yaml:"idle-conn-timeout" description:"How long an idle connection to the Thunderstorm server is kept open before being closed."`
+	PingInterval        time.Duration `yaml:"ping-interval" description:"Interval at which idle HTTP/2 connections are health-checked with a PING frame.\nSet to 0 to disable."`
+
+	ClientCert          string `yaml:"client-cert" description:"Path to a PEM or PKCS#12 (.p12/.pfx) client certificate presented to Thunderstorm deployments that require mTLS."`
+	ClientKey           string `yaml:"client-key" description:"Path to the PEM private key matching --client-cert. Not needed when --client-cert is a PKCS#12 bundle."`
+	ClientKeyPassphrase string `yaml:"client-key-passphrase" description:"Passphrase protecting --client-key (or the PKCS#12 bundle given as --client-cert)." hidden:"true"`
+
+	TLSMinVersion string `yaml:"tls-min-version" description:"Minimum TLS version to negotiate with the Thunderstorm server: \"1.2\" or \"1.3\"."`
+	TLSProfile    string `yaml:"tls-profile" description:"Cipher suite and curve preferences to apply, following the Mozilla TLS guidelines: \"modern\", \"intermediate\" or \"legacy\"."`
+
+	AdminListen string `yaml:"admin-listen" description:"Address (e.g. :9200) on which to expose /healthz, /readyz and /metrics for running the collector as a long-lived service.\nLeft empty, no admin server is started."`
+
+	MaxResponseSize int64 `yaml:"max-response-size" description:"Maximum size (in MB) read from a Thunderstorm scan-result response, independently of --max-filesize.\nProtects against a hostile or misconfigured server returning an unbounded body."`
 }
 
 var DefaultConfig = Config{
-	Threads:     1,
-	MaxFileSize: 100,
-	RootPaths:   []string{getRootPath()},
-	Source:      HostnameOrBlank(),
+	Threads:         1,
+	MaxFileSize:     100,
+	MaxResponseSize: 100,
+	ChunkSize:       50,
+	RootPaths:       []string{getRootPath()},
+	Source:          HostnameOrBlank(),
+
+	MaxConnsPerHost:     8,
+	MaxIdleConnsPerHost: 8,
+	IdleConnTimeout:     90 * time.Second,
+	PingInterval:        15 * time.Second,
+
+	TLSMinVersion: "1.2",
+	TLSProfile:    "intermediate",
 }
 
 func HostnameOrBlank() string {
@@ -85,6 +116,28 @@ func ParseConfig() Config {
 		flags.Usage()
 		os.Exit(1)
 	}
+	if config.Resume && config.ResumeState == "" {
+		fmt.Fprintln(os.Stderr, "--resume requires --resume-state to be set")
+		os.Exit(1)
+	}
+	if config.ChunkSize < 1 {
+		fmt.Fprintln(os.Stderr, "Chunk size must be >= 1")
+		os.Exit(1)
+	}
+	if config.MaxResponseSize < 1 {
+		fmt.Fprintln(os.Stderr, "Maximum response size must be >= 1")
+		os.Exit(1)
+	}
+	if config.TLSMinVersion != "1.2" && config.TLSMinVersion != "1.3" {
+		fmt.Fprintln(os.Stderr, "--tls-min-version must be \"1.2\" or \"1.3\"")
+		os.Exit(1)
+	}
+	switch config.TLSProfile {
+	case "modern", "intermediate", "legacy":
+	default:
+		fmt.Fprintln(os.Stderr, "--tls-profile must be \"modern\", \"intermediate\" or \"legacy\"")
+		os.Exit(1)
+	}
 	config.Server = strings.TrimSuffix(config.Server, "/")
 
 	if !(strings.HasPrefix(config.Server, "http://") || strings.HasPrefix(config.Server, "https://")) {
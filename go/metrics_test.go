@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestSetQueueDepthUpdatesCurrentQueueDepth(t *testing.T) {
+	SetQueueDepth(42)
+	defer SetQueueDepth(0)
+	if got := currentQueueDepth(); got != 42 {
+		t.Fatalf("currentQueueDepth() = %d, want 42", got)
+	}
+}
+
+func TestRecordUploadUpdatesCountersWithoutPanicking(t *testing.T) {
+	RecordUpload(true, 0, 0)
+	RecordUpload(false, 0, 0)
+}
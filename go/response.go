@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ReadLimitedResponse reads a Thunderstorm scan-result response body,
+// bounded by maxSizeMB (--max-response-size) so that a hostile or
+// misconfigured server cannot make the collector allocate unbounded
+// memory. It reports an error instead of silently truncating when the
+// body turns out to be larger than the limit.
+func ReadLimitedResponse(resp *http.Response, maxSizeMB int64) ([]byte, error) {
+	limit := maxSizeMB * 1024 * 1024
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response body exceeds --max-response-size (%d MB)", maxSizeMB)
+	}
+	return body, nil
+}
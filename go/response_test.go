@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func responseWithBody(body string) *http.Response {
+	return &http.Response{Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestReadLimitedResponseUnderLimit(t *testing.T) {
+	body := "hello world"
+	got, err := ReadLimitedResponse(responseWithBody(body), 1)
+	if err != nil {
+		t.Fatalf("ReadLimitedResponse: %v", err)
+	}
+	if string(got) != body {
+		t.Fatalf("got %q, want %q", got, body)
+	}
+}
+
+func TestReadLimitedResponseAtLimit(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 1024*1024)
+	got, err := ReadLimitedResponse(responseWithBody(string(body)), 1)
+	if err != nil {
+		t.Fatalf("ReadLimitedResponse at exact limit: %v", err)
+	}
+	if len(got) != len(body) {
+		t.Fatalf("got %d bytes, want %d", len(got), len(body))
+	}
+}
+
+func TestReadLimitedResponseOverLimit(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 1024*1024+1)
+	_, err := ReadLimitedResponse(responseWithBody(string(body)), 1)
+	if err == nil {
+		t.Fatalf("expected error for response body exceeding --max-response-size")
+	}
+}
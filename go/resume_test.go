@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResumeStoreUpdateConcurrentSurvivesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resume.json")
+	store, err := OpenResumeStore(path)
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := FileKey(filepath.Join("file", string(rune('a'+i%26))), int64(i), time.Unix(int64(i), 0))
+			if err := store.Update(key, 100, int64(i), time.Unix(int64(i), 0)); err != nil {
+				t.Errorf("Update: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, err := OpenResumeStore(path)
+	if err != nil {
+		t.Fatalf("reloading journal after concurrent updates: %v", err)
+	}
+	if len(reloaded.entries) != 50 {
+		t.Fatalf("expected 50 journal entries after concurrent updates, got %d", len(reloaded.entries))
+	}
+}
+
+func TestResumeStoreUpdateMarksDone(t *testing.T) {
+	store, err := OpenResumeStore(filepath.Join(t.TempDir(), "resume.json"))
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+	key := FileKey("/tmp/evidence.img", 100, time.Unix(0, 0))
+
+	if err := store.Update(key, 100, 50, time.Unix(0, 0)); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if store.Done(key) {
+		t.Fatalf("expected file not done at offset 50/100")
+	}
+
+	if err := store.Update(key, 100, 100, time.Unix(0, 0)); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !store.Done(key) {
+		t.Fatalf("expected file done at offset 100/100")
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+
+	if d := b.Delay(0); d < b.Base || d > b.Base+b.Base/5 {
+		t.Fatalf("Delay(0) = %v, want in [%v, %v]", d, b.Base, b.Base+b.Base/5)
+	}
+
+	// Large attempts must clamp to Max (plus jitter), never grow unbounded.
+	d := b.Delay(20)
+	if d < b.Max || d > b.Max+b.Max/5 {
+		t.Fatalf("Delay(20) = %v, want clamped to [%v, %v]", d, b.Max, b.Max+b.Max/5)
+	}
+}
+
+func TestResumeStoreUpdateAppendsWithoutRewritingHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resume.json")
+	store, err := OpenResumeStore(path)
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+
+	var sizes []int64
+	for i := 0; i < 20; i++ {
+		key := FileKey(filepath.Join("file", string(rune('a'+i))), 100, time.Unix(int64(i), 0))
+		if err := store.Update(key, 100, 50, time.Unix(int64(i), 0)); err != nil {
+			t.Fatalf("Update %d: %v", i, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat journal: %v", err)
+		}
+		sizes = append(sizes, info.Size())
+	}
+
+	// Each Update should only append its own record, so the journal grows
+	// by roughly the same amount every time rather than by an amount that
+	// scales with how many entries are already tracked.
+	first := sizes[1] - sizes[0]
+	last := sizes[len(sizes)-1] - sizes[len(sizes)-2]
+	if last > first*2 {
+		t.Fatalf("journal growth per Update is not constant: first delta %d, last delta %d", first, last)
+	}
+}
+
+func TestUploadChunkSetsTusHeadersAndRetriesOn503(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("method = %s, want PATCH", r.Method)
+		}
+		if got := r.Header.Get("Upload-Offset"); got != "10" {
+			t.Errorf("Upload-Offset = %q, want %q", got, "10")
+		}
+		if got := r.Header.Get("Upload-Length"); got != "30" {
+			t.Errorf("Upload-Length = %q, want %q", got, "30")
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	backoff := Backoff{Base: time.Millisecond, Max: time.Millisecond, Factor: 1}
+	err := UploadChunk(server.Client(), server.URL, []byte("0123456789"), 10, 30, backoff)
+	if err != nil {
+		t.Fatalf("UploadChunk: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts (1 retry after 503), got %d", attempts)
+	}
+}
+
+func TestUploadChunkGivesUpAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	backoff := Backoff{Base: time.Millisecond, Max: time.Millisecond, Factor: 1}
+	err := UploadChunk(server.Client(), server.URL, []byte("chunk"), 0, 5, backoff)
+	if err == nil {
+		t.Fatalf("expected error after repeated 429 responses")
+	}
+}
+
+func TestUploadFileResumableResumesFromJournal(t *testing.T) {
+	const content = "the quick brown fox jumps over the lazy dog"
+
+	path := filepath.Join(t.TempDir(), "evidence.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	var received []byte
+	var failNextPatch int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.CompareAndSwapInt32(&failNextPatch, 1, 0) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, body...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	journal := filepath.Join(t.TempDir(), "resume.json")
+	store, err := OpenResumeStore(journal)
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+
+	config := DefaultConfig
+	config.Server = server.URL
+
+	if err := UploadFileResumable(server.Client(), config, store, server.URL, path); err != nil {
+		t.Fatalf("UploadFileResumable: %v", err)
+	}
+	if string(received) != content {
+		t.Fatalf("server received %q, want %q", received, content)
+	}
+
+	key := FileKey(path, info.Size(), info.ModTime())
+	if !store.Done(key) {
+		t.Fatalf("expected file marked done after successful upload")
+	}
+
+	// A second upload of the same file should be a no-op: the journal
+	// already has it recorded as done.
+	received = nil
+	if err := UploadFileResumable(server.Client(), config, store, server.URL, path); err != nil {
+		t.Fatalf("UploadFileResumable (already done): %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("expected no chunks re-uploaded for an already-done file, got %d bytes", len(received))
+	}
+}
+
+func TestUploadFileResumableMarksEmptyFileDone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request for an empty file: %s", r.URL)
+	}))
+	defer server.Close()
+
+	store, err := OpenResumeStore(filepath.Join(t.TempDir(), "resume.json"))
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+	config := DefaultConfig
+	config.Server = server.URL
+
+	if err := UploadFileResumable(server.Client(), config, store, server.URL, path); err != nil {
+		t.Fatalf("UploadFileResumable: %v", err)
+	}
+	if !store.Done(FileKey(path, info.Size(), info.ModTime())) {
+		t.Fatalf("expected empty file marked done")
+	}
+}
+
+func TestUploadFileResumableErrorsWhenFileShrinksMidUpload(t *testing.T) {
+	const chunkBytes = 1 * 1024 * 1024
+
+	path := filepath.Join(t.TempDir(), "shrinking.bin")
+	content := bytes.Repeat([]byte("a"), chunkBytes+chunkBytes/2)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var chunks int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&chunks, 1) == 1 {
+			// Simulate the file being truncated out from under the
+			// collector between chunks, as if replaced or rotated mid-run.
+			if err := os.Truncate(path, chunkBytes); err != nil {
+				t.Fatalf("Truncate: %v", err)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store, err := OpenResumeStore(filepath.Join(t.TempDir(), "resume.json"))
+	if err != nil {
+		t.Fatalf("OpenResumeStore: %v", err)
+	}
+	config := DefaultConfig
+	config.Server = server.URL
+	config.ChunkSize = 1
+
+	done := make(chan error, 1)
+	go func() { done <- UploadFileResumable(server.Client(), config, store, server.URL, path) }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected error when file shrinks mid-upload instead of looping forever")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("UploadFileResumable did not return - looping forever on a shrunk file")
+	}
+}
+
+func TestChunkCount(t *testing.T) {
+	cases := []struct {
+		size, chunkSizeMB, want int64
+	}{
+		{size: 0, chunkSizeMB: 10, want: 0},
+		{size: 1, chunkSizeMB: 10, want: 1},
+		{size: 10 * 1024 * 1024, chunkSizeMB: 10, want: 1},
+		{size: 10*1024*1024 + 1, chunkSizeMB: 10, want: 2},
+		{size: 25 * 1024 * 1024, chunkSizeMB: 10, want: 3},
+		{size: 25 * 1024 * 1024, chunkSizeMB: 0, want: ChunkCount(25*1024*1024, DefaultConfig.ChunkSize)},
+	}
+	for _, c := range cases {
+		if got := ChunkCount(c.size, c.chunkSizeMB); got != c.want {
+			t.Errorf("ChunkCount(%d, %d) = %d, want %d", c.size, c.chunkSizeMB, got, c.want)
+		}
+	}
+}
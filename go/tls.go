@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientCertStore holds the client certificate presented for mTLS and
+// reloads it from disk on SIGHUP, so long-running collectors on servers
+// can pick up rotated certificates without a restart.
+type ClientCertStore struct {
+	certPath   string
+	keyPath    string
+	passphrase string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewClientCertStore loads the client keypair at certPath/keyPath once
+// and returns a store that keeps it up to date. If certPath is empty,
+// the store serves no certificate: GetClientCertificate returns an empty
+// (but non-nil) *tls.Certificate, which is fine for servers that don't
+// require mTLS.
+func NewClientCertStore(certPath, keyPath, passphrase string) (*ClientCertStore, error) {
+	store := &ClientCertStore{certPath: certPath, keyPath: keyPath, passphrase: passphrase}
+	if certPath == "" {
+		return store, nil
+	}
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *ClientCertStore) reload() error {
+	cert, err := LoadClientCertificate(s.certPath, s.keyPath, s.passphrase)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cert = &cert
+	s.mu.Unlock()
+	return nil
+}
+
+// GetClientCertificate is suitable for tls.Config.GetClientCertificate.
+func (s *ClientCertStore) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return s.cert, nil
+}
+
+// WatchSIGHUP reloads the client certificate whenever the process
+// receives SIGHUP, logging but not exiting on failure so a bad rotation
+// doesn't kill an otherwise healthy collector.
+func (s *ClientCertStore) WatchSIGHUP() {
+	if s.certPath == "" {
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := s.reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "reloading client certificate: %v\n", err)
+			}
+		}
+	}()
+}
+
+// LoadClientCertificate reads a client keypair for mTLS from certPath and
+// keyPath. A PKCS#12 bundle (.p12/.pfx) is accepted in place of a PEM
+// pair, in which case keyPath is ignored and passphrase decrypts it.
+func LoadClientCertificate(certPath, keyPath, passphrase string) (tls.Certificate, error) {
+	ext := strings.ToLower(filepath.Ext(certPath))
+	if ext == ".p12" || ext == ".pfx" {
+		data, err := os.ReadFile(certPath)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("reading client certificate %q: %w", certPath, err)
+		}
+		privateKey, cert, err := pkcs12.Decode(data, passphrase)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("decoding PKCS#12 client certificate %q: %w", certPath, err)
+		}
+		return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: privateKey, Leaf: cert}, nil
+	}
+
+	if passphrase == "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("loading client certificate %q/%q: %w", certPath, keyPath, err)
+		}
+		return cert, nil
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("reading client certificate %q: %w", certPath, err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("reading client key %q: %w", keyPath, err)
+	}
+	keyPEM, err = DecryptPEMKey(keyPEM, passphrase)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("decrypting client key %q: %w", keyPath, err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading client certificate %q/%q: %w", certPath, keyPath, err)
+	}
+	return cert, nil
+}
+
+// DecryptPEMKey decrypts a passphrase-protected PEM private key block.
+// Encrypted PEM is a legacy format (superseded by PKCS#8/PKCS#12) but
+// it's still what most internal CAs hand out alongside a client cert,
+// so --client-key-passphrase needs to support it.
+func DecryptPEMKey(keyPEM []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in client key")
+	}
+	if !x509.IsEncryptedPEMBlock(block) { //nolint:staticcheck // encrypted PEM is legacy but still widely issued
+		return keyPEM, nil
+	}
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase)) //nolint:staticcheck // see above
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+// NewTLSConfig builds the tls.Config used for connections to the
+// Thunderstorm server from the configured CA pins, --insecure flag and,
+// if set, the client certificate used for mTLS.
+func NewTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+
+	if err := ApplyTLSProfile(tlsConfig, config.TLSProfile, config.TLSMinVersion); err != nil {
+		return nil, err
+	}
+
+	if len(config.CAs) > 0 {
+		pool := x509.NewCertPool()
+		for _, ca := range config.CAs {
+			pem, err := os.ReadFile(ca)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA certificate %q: %w", ca, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %q", ca)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCert != "" {
+		store, err := NewClientCertStore(config.ClientCert, config.ClientKey, config.ClientKeyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		store.WatchSIGHUP()
+		tlsConfig.GetClientCertificate = store.GetClientCertificate
+	}
+
+	return tlsConfig, nil
+}
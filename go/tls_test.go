@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestDecryptPEMKeyPlainPassthrough(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	got, err := DecryptPEMKey(keyPEM, "unused")
+	if err != nil {
+		t.Fatalf("DecryptPEMKey on unencrypted PEM: %v", err)
+	}
+	if string(got) != string(keyPEM) {
+		t.Fatalf("expected unencrypted PEM to be returned unchanged")
+	}
+}
+
+func TestDecryptPEMKeyEncrypted(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der := x509.MarshalPKCS1PrivateKey(key)
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", der, []byte("hunter2"), x509.PEMCipherAES256) //nolint:staticcheck // exercising the legacy format DecryptPEMKey supports
+	if err != nil {
+		t.Fatalf("encrypting PEM block: %v", err)
+	}
+	encryptedPEM := pem.EncodeToMemory(block)
+
+	decrypted, err := DecryptPEMKey(encryptedPEM, "hunter2")
+	if err != nil {
+		t.Fatalf("DecryptPEMKey with correct passphrase: %v", err)
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(mustPEMBytes(t, decrypted)); err != nil {
+		t.Fatalf("decrypted key did not parse: %v", err)
+	}
+
+	if _, err := DecryptPEMKey(encryptedPEM, "wrong"); err == nil {
+		t.Fatalf("expected error decrypting with wrong passphrase")
+	}
+}
+
+func mustPEMBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("expected a PEM block")
+	}
+	return block.Bytes
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// tlsVersions maps the --tls-min-version flag to its crypto/tls constant.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// modernCipherSuites, intermediateCipherSuites and legacyCipherSuites
+// follow the Mozilla TLS configuration guidelines
+// (https://wiki.mozilla.org/Security/Server_Side_TLS). TLS 1.3 cipher
+// suites aren't listed here since crypto/tls picks its own fixed set
+// whenever 1.3 is negotiated.
+var (
+	modernCipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	}
+
+	intermediateCipherSuites = append(append([]uint16{}, modernCipherSuites...),
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	)
+
+	legacyCipherSuites = append(append([]uint16{}, intermediateCipherSuites...),
+		tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	)
+)
+
+// ApplyTLSProfile sets cipher suites and curve preferences on tlsConfig
+// according to the Mozilla guideline level named by profile, and enforces
+// minVersion ("1.2" or "1.3") as the floor. This lets operators refuse to
+// talk to Thunderstorm endpoints that have been downgraded, rather than
+// relying only on --insecure as an escape hatch.
+func ApplyTLSProfile(tlsConfig *tls.Config, profile string, minVersion string) error {
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return fmt.Errorf("unknown TLS minimum version %q", minVersion)
+	}
+	tlsConfig.MinVersion = version
+
+	switch profile {
+	case "modern":
+		tlsConfig.CipherSuites = modernCipherSuites
+		tlsConfig.CurvePreferences = []tls.CurveID{tls.X25519, tls.CurveP256}
+	case "intermediate":
+		tlsConfig.CipherSuites = intermediateCipherSuites
+		tlsConfig.CurvePreferences = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+	case "legacy":
+		tlsConfig.CipherSuites = legacyCipherSuites
+		tlsConfig.CurvePreferences = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384, tls.CurveP521}
+	default:
+		return fmt.Errorf("unknown TLS profile %q", profile)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestApplyTLSProfile(t *testing.T) {
+	cases := []struct {
+		profile       string
+		wantSuites    []uint16
+		wantMinCurves int
+	}{
+		{profile: "modern", wantSuites: modernCipherSuites, wantMinCurves: 2},
+		{profile: "intermediate", wantSuites: intermediateCipherSuites, wantMinCurves: 3},
+		{profile: "legacy", wantSuites: legacyCipherSuites, wantMinCurves: 4},
+	}
+	for _, c := range cases {
+		cfg := &tls.Config{}
+		if err := ApplyTLSProfile(cfg, c.profile, "1.2"); err != nil {
+			t.Fatalf("ApplyTLSProfile(%q): %v", c.profile, err)
+		}
+		if len(cfg.CipherSuites) != len(c.wantSuites) {
+			t.Errorf("%s: got %d cipher suites, want %d", c.profile, len(cfg.CipherSuites), len(c.wantSuites))
+		}
+		if len(cfg.CurvePreferences) < c.wantMinCurves {
+			t.Errorf("%s: got %d curves, want at least %d", c.profile, len(cfg.CurvePreferences), c.wantMinCurves)
+		}
+		if cfg.MinVersion != tls.VersionTLS12 {
+			t.Errorf("%s: MinVersion = %x, want TLS 1.2", c.profile, cfg.MinVersion)
+		}
+	}
+}
+
+func TestApplyTLSProfileMinVersion(t *testing.T) {
+	cfg := &tls.Config{}
+	if err := ApplyTLSProfile(cfg, "intermediate", "1.3"); err != nil {
+		t.Fatalf("ApplyTLSProfile: %v", err)
+	}
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want TLS 1.3", cfg.MinVersion)
+	}
+}
+
+func TestApplyTLSProfileUnknownValues(t *testing.T) {
+	if err := ApplyTLSProfile(&tls.Config{}, "intermediate", "1.1"); err == nil {
+		t.Fatalf("expected error for unknown TLS minimum version")
+	}
+	if err := ApplyTLSProfile(&tls.Config{}, "bogus", "1.2"); err == nil {
+		t.Fatalf("expected error for unknown TLS profile")
+	}
+}
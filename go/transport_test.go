@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProxyFuncDefaultsToEnvironment(t *testing.T) {
+	config := DefaultConfig
+	proxy, err := proxyFunc(config)
+	if err != nil {
+		t.Fatalf("proxyFunc: %v", err)
+	}
+	if proxy == nil {
+		t.Fatalf("expected a non-nil proxy func when --http-proxy is unset")
+	}
+}
+
+func TestProxyFuncInvalidURL(t *testing.T) {
+	config := DefaultConfig
+	config.Proxy = "://not-a-url"
+	if _, err := proxyFunc(config); err == nil {
+		t.Fatalf("expected error for invalid --http-proxy %q", config.Proxy)
+	}
+}
+
+func TestNewHTTPClientRejectsInvalidProxy(t *testing.T) {
+	config := DefaultConfig
+	config.Server = "https://thunderstorm.example"
+	config.Proxy = "://not-a-url"
+	if _, err := NewHTTPClient(config); err == nil {
+		t.Fatalf("expected NewHTTPClient to surface the invalid --http-proxy error")
+	}
+}
+
+func TestNewHTTPClientNegotiatesHTTP2OverTLS(t *testing.T) {
+	config := DefaultConfig
+	config.Server = "https://thunderstorm.example"
+
+	client, err := NewHTTPClient(config)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport is %T, want *http.Transport", client.Transport)
+	}
+	if _, ok := transport.TLSNextProto["h2"]; !ok {
+		t.Fatalf("expected TLSNextProto to be configured for h2 over an https:// server")
+	}
+}
+
+func TestNewHTTPClientFallsBackToHTTP1WithoutTLS(t *testing.T) {
+	config := DefaultConfig
+	config.Server = "http://thunderstorm.example"
+
+	client, err := NewHTTPClient(config)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport is %T, want *http.Transport", client.Transport)
+	}
+	if len(transport.TLSNextProto) != 0 {
+		t.Fatalf("expected no HTTP/2 negotiation configured for a plain http:// server, got %v", transport.TLSNextProto)
+	}
+}